@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is the location of the configuration file relative to the
+// working directory the tool is invoked from.
+const defaultConfigPath = "config.yaml"
+
+// Config holds the settings that used to be hardcoded constants in main.go.
+// It lets the tool be pointed at a different Sparx/EA export (different
+// archive folders, a different "interesting" type, a different short-name
+// convention) without a recompile.
+type Config struct {
+	// IgnoredParentIds lists the IDs of folders whose entries should be
+	// dropped during preprocessing (e.g. archive folders).
+	IgnoredParentIds []int `yaml:"ignoredParentIds"`
+	// IncludedTypeNames lists the entry type names that should be retained.
+	// An entry is kept only if its TypeName is in this list.
+	IncludedTypeNames []string `yaml:"includedTypeNames"`
+	// ShortNameRegex is used by Component.ShortName to extract the short,
+	// human-friendly identifier from a component's full name.
+	ShortNameRegex string `yaml:"shortNameRegex"`
+	// InputPath is the path to the data.json export to read.
+	InputPath string `yaml:"inputPath"`
+	// OutputDir is the directory diagrams are written to.
+	OutputDir string `yaml:"outputDir"`
+	// OutputFormats lists the diagram formats to generate for each component.
+	// Supported values are "svg", "png", "d2" (the raw D2 source), "dot",
+	// "graphml", and "mermaid".
+	OutputFormats []string `yaml:"outputFormats"`
+	// D2BinaryPath is the path to the d2 CLI binary, used to rasterize PNGs
+	// since the d2 Go library does not ship a built-in rasterizer.
+	D2BinaryPath string `yaml:"d2BinaryPath"`
+	// DependencyTypeStyles maps a DirectReference.DependencyType code to the
+	// label and color it should be rendered with in diagrams.
+	DependencyTypeStyles map[int]DependencyStyle `yaml:"dependencyTypeStyles"`
+}
+
+// DependencyStyle describes how a dependency type code should be rendered:
+// a human-readable label (e.g. "reads") and a D2 stroke color.
+type DependencyStyle struct {
+	Label string `yaml:"label"`
+	Color string `yaml:"color"`
+}
+
+// LoadConfig reads and parses the YAML configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	return &cfg, nil
+}
@@ -3,13 +3,20 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"oss.terrastruct.com/d2/d2graph"
 	"oss.terrastruct.com/d2/d2layouts/d2elklayout"
@@ -21,13 +28,21 @@ import (
 	"oss.terrastruct.com/util-go/go2"
 )
 
-var NAME_REGEX = "^([0-9\\.]+).*"                                                                            // match only number code
-var IGNORE_WITH_PARENT_ID = []int{24200, 24225, 24532, 25061, 25083, 24413, 24374, 24738, 25211, 230, 23795} // IDs of archive folders to ignore
-
-// main reads a data.json file, preprocesses its entries, resolves their dependencies
-// and dependents, and then generates a SVG diagram for each component.
+// main loads the config file, reads the configured data.json file, preprocesses
+// its entries, resolves their dependencies and dependents, writes the system
+// overview diagram and graph exports, and then generates a diagram in each
+// configured output format for each component.
 func main() {
-	data, err := os.ReadFile("data.json")
+	upstreamDepth := flag.Int("upstream-depth", 1, "number of hops to traverse for upstream (incoming) data flows; negative means unbounded")
+	downstreamDepth := flag.Int("downstream-depth", 1, "number of hops to traverse for downstream (outgoing) data flows; negative means unbounded")
+	flag.Parse()
+
+	cfg, err := LoadConfig(defaultConfigPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cfg.InputPath)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -37,16 +52,55 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// folder names are discarded by Preprocess (it drops folder entries), so
+	// collect them up front for the system overview diagram's cluster labels.
+	folders := FolderNames(entries)
+
 	// 1. Prepocessing
-	preprocessed := Preprocess(entries)
+	preprocessed := Preprocess(entries, cfg)
 
 	// 2. Resolve relations (dependencies and dependents)
 	store := ResolveRelations(preprocessed)
 
+	// 3. Render the whole-system overview diagram, clustered by parent folder,
+	// plus exports of the resolved graph for downstream tooling.
+	systemSvg := RenderSystemDiagram(store, folders, cfg)
+	if err := os.WriteFile(filepath.Join(cfg.OutputDir, "system.svg"), systemSvg, 0600); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.OutputDir, "system.dot"), []byte(StoreToDOT(store, cfg)), 0600); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.OutputDir, "system.graphml"), []byte(StoreToGraphML(store, cfg)), 0600); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.OutputDir, "system.mmd"), []byte(StoreToMermaid(store, cfg)), 0600); err != nil {
+		log.Fatal(err)
+	}
+
+	// 4. Render each component's diagram. Diagram rendering is the dominant
+	// cost and each component is independent, so fan the work out across a
+	// worker pool sized to the number of available CPUs.
+	jobs := make(chan *Component)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for comp := range jobs {
+				log.Printf("Generating %s\n", comp.Name)
+				if err := comp.Save(cfg, cfg.OutputFormats, cfg.OutputDir, *upstreamDepth, *downstreamDepth); err != nil {
+					log.Printf("generating %s: %v", comp.Name, err)
+				}
+			}
+		}()
+	}
+
 	for _, comp := range store {
-		log.Printf("Generating %s\n", comp.Name)
-		comp.SaveAsSvg()
+		jobs <- comp
 	}
+	close(jobs)
+	wg.Wait()
 }
 
 type DirectReference struct {
@@ -67,42 +121,69 @@ type Entry struct {
 }
 
 // AsComponent converts an Entry into a Component.
-// It initializes the component with the entry's ID, name, and type name.
-// The component's dependencies and dependents are initially empty.
+// It initializes the component with the entry's ID, parent folder ID, name,
+// and type name. The component's dependencies and dependents are initially
+// empty.
 func (e *Entry) AsComponent() *Component {
 	return &Component{
 		Id:           e.Id,
+		ParentId:     e.ParentId,
 		Name:         e.Name,
 		TypeName:     e.TypeName,
-		Dependencies: []*Component{},
-		Dependents:   []*Component{},
+		Dependencies: []*Edge{},
+		Dependents:   []*Edge{},
 	}
 }
 
+// FolderNames returns a lookup from folder ID to folder name for every folder
+// entry (IsFolder == true). Preprocess discards folder entries entirely, so
+// this must be collected from the raw entries before preprocessing if the
+// folder names are needed later (e.g. to label clusters in the system
+// overview diagram).
+func FolderNames(entries []Entry) map[int]string {
+	names := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsFolder {
+			names[entry.Id] = entry.Name
+		}
+	}
+	return names
+}
+
 type Component struct {
 	Id           int
+	ParentId     int
 	Name         string
 	TypeName     string
-	Dependencies []*Component
-	Dependents   []*Component
+	Dependencies []*Edge
+	Dependents   []*Edge
 }
 
-// AddDependency adds a dependency component reference to the component.
-// This adds a incoming data flow to the component.
-func (c *Component) AddDependency(dep *Component) {
-	c.Dependencies = append(c.Dependencies, dep)
+// Edge is a single directed data flow between two components, carrying the
+// dependency type codes (Entry.DirectReferences[].dependencyType) that
+// describe the nature of the flow.
+type Edge struct {
+	From           *Component
+	To             *Component
+	DependencyType []int
 }
 
-// AddDependent adds a dependent component reference to the component.
-// This adds an outgoing data flow to the component.
-func (c *Component) AddDependent(dep *Component) {
-	c.Dependents = append(c.Dependents, dep)
+// AddDependency adds an incoming data flow to the component, from dep, typed
+// by depType.
+func (c *Component) AddDependency(dep *Component, depType []int) {
+	c.Dependencies = append(c.Dependencies, &Edge{From: dep, To: c, DependencyType: depType})
 }
 
-// ShortName returns the short name of the component, using a regular expression.
-// Currently this is the number of the component.
-func (c *Component) ShortName() string {
-	re := regexp.MustCompile(NAME_REGEX)
+// AddDependent adds an outgoing data flow from the component, to dep, typed
+// by depType.
+func (c *Component) AddDependent(dep *Component, depType []int) {
+	c.Dependents = append(c.Dependents, &Edge{From: c, To: dep, DependencyType: depType})
+}
+
+// ShortName returns the short name of the component, using the regular expression
+// configured as cfg.ShortNameRegex. Currently this is the number of the component.
+func (c *Component) ShortName(cfg *Config) string {
+	re := regexp.MustCompile(cfg.ShortNameRegex)
 	matches := re.FindStringSubmatch(c.Name)
 
 	if len(matches) >= 2 {
@@ -112,24 +193,166 @@ func (c *Component) ShortName() string {
 	return c.Name
 }
 
-// SaveAsSvg generates a SVG diagram of the component's data flow and saves it to disk.
-// The filename is the short name of the component, and the file is saved in the
-// diagrams/ directory.
-func (c *Component) SaveAsSvg() {
-	svg := c.RenderSvg()
+// edgeKey identifies an edge between two component IDs, used to dedupe edges
+// discovered while traversing the graph (Edge itself isn't comparable, since
+// DependencyType is a slice).
+type edgeKey struct {
+	From int
+	To   int
+}
 
-	err := os.WriteFile(filepath.Join("./diagrams/", fmt.Sprintf("%s.svg", c.ShortName())), svg, 0600)
-	if err != nil {
-		log.Fatal(err)
+// TraverseUpstream walks the dependency graph upstream (incoming data flows)
+// starting at c, up to depth hops away. A depth < 0 traverses the whole
+// upstream graph. Each edge is returned only once.
+func (c *Component) TraverseUpstream(depth int, cfg *Config) []Edge {
+	return bfsEdges(c, depth, func(cur *Component) []edgeStep {
+		var steps []edgeStep
+		for _, dep := range cur.Dependencies {
+			if !slices.Contains(cfg.IncludedTypeNames, dep.From.TypeName) || dep.From.Id == cur.Id {
+				continue
+			}
+			steps = append(steps, edgeStep{
+				neighbor: dep.From,
+				edge:     Edge{From: dep.From, To: cur, DependencyType: dep.DependencyType},
+			})
+		}
+		return steps
+	})
+}
+
+// TraverseDownstream walks the dependency graph downstream (outgoing data
+// flows) starting at c, up to depth hops away. A depth < 0 traverses the whole
+// downstream graph. Each edge is returned only once.
+func (c *Component) TraverseDownstream(depth int, cfg *Config) []Edge {
+	return bfsEdges(c, depth, func(cur *Component) []edgeStep {
+		var steps []edgeStep
+		for _, dep := range cur.Dependents {
+			if !slices.Contains(cfg.IncludedTypeNames, dep.To.TypeName) || dep.To.Id == cur.Id {
+				continue
+			}
+			steps = append(steps, edgeStep{
+				neighbor: dep.To,
+				edge:     Edge{From: cur, To: dep.To, DependencyType: dep.DependencyType},
+			})
+		}
+		return steps
+	})
+}
+
+// edgeStep pairs a component adjacent to the one currently being expanded
+// with the Edge connecting them.
+type edgeStep struct {
+	neighbor *Component
+	edge     Edge
+}
+
+// bfsEdges breadth-first-traverses the graph reachable from start via
+// step(cur), which returns cur's neighbors for the direction being walked
+// (upstream or downstream). It explores up to depth hops away (unbounded
+// when depth < 0) and returns each discovered edge only once.
+//
+// A node is re-queued whenever it's reached with a larger remaining hop
+// budget than the best one seen for it so far. This matters for
+// diamond-shaped graphs: a plain DFS-with-visited-set would permanently cap a
+// node's exploration at whichever hop count first reached it, so if the
+// first path in is the longest one, a shorter path arriving later would be
+// unable to explore that node's own edges even though they're within depth.
+func bfsEdges(start *Component, depth int, step func(cur *Component) []edgeStep) []Edge {
+	maxHops := depth
+	if maxHops < 0 {
+		maxHops = math.MaxInt
 	}
+
+	type queued struct {
+		comp     *Component
+		hopsLeft int
+	}
+
+	best := map[int]int{start.Id: maxHops}
+	seenEdges := map[edgeKey]bool{}
+	var edges []Edge
+
+	queue := []queued{{comp: start, hopsLeft: maxHops}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur.hopsLeft == 0 {
+			continue
+		}
+
+		for _, s := range step(cur.comp) {
+			key := edgeKey{From: s.edge.From.Id, To: s.edge.To.Id}
+			if !seenEdges[key] {
+				seenEdges[key] = true
+				edges = append(edges, s.edge)
+			}
+
+			nextHops := cur.hopsLeft - 1
+			if b, ok := best[s.neighbor.Id]; ok && b >= nextHops {
+				continue
+			}
+			best[s.neighbor.Id] = nextHops
+			queue = append(queue, queued{comp: s.neighbor, hopsLeft: nextHops})
+		}
+	}
+
+	return edges
+}
+
+// Save renders the component's data flow diagram and writes it to outDir in
+// each of the given formats. Supported formats are "svg", "png", "d2" (the
+// raw D2 diagram source), "dot" (Graphviz), "graphml", and "mermaid".
+// upstreamDepth and downstreamDepth control how many hops the diagram
+// traverses in each direction.
+func (c *Component) Save(cfg *Config, formats []string, outDir string, upstreamDepth, downstreamDepth int) error {
+	name := c.ShortName(cfg)
+	contents := c.FullDiagram(cfg, upstreamDepth, downstreamDepth)
+
+	for _, format := range formats {
+		switch format {
+		case "d2":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.d2", name))
+			if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+				return fmt.Errorf("writing d2 source for %s: %w", name, err)
+			}
+		case "svg":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.svg", name))
+			if err := os.WriteFile(path, renderSvg(contents), 0600); err != nil {
+				return fmt.Errorf("writing svg for %s: %w", name, err)
+			}
+		case "png":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.png", name))
+			if err := renderPng(cfg, contents, path); err != nil {
+				return fmt.Errorf("writing png for %s: %w", name, err)
+			}
+		case "dot":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.dot", name))
+			if err := os.WriteFile(path, []byte(c.ToDOT(cfg, upstreamDepth, downstreamDepth)), 0600); err != nil {
+				return fmt.Errorf("writing dot for %s: %w", name, err)
+			}
+		case "graphml":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.graphml", name))
+			if err := os.WriteFile(path, []byte(c.ToGraphML(cfg, upstreamDepth, downstreamDepth)), 0600); err != nil {
+				return fmt.Errorf("writing graphml for %s: %w", name, err)
+			}
+		case "mermaid":
+			path := filepath.Join(outDir, fmt.Sprintf("%s.mmd", name))
+			if err := os.WriteFile(path, []byte(c.ToMermaid(cfg, upstreamDepth, downstreamDepth)), 0600); err != nil {
+				return fmt.Errorf("writing mermaid for %s: %w", name, err)
+			}
+		default:
+			return fmt.Errorf("unsupported diagram format %q", format)
+		}
+	}
+
+	return nil
 }
 
-// RenderSvg compiles the component's full diagram into a SVG representation.
+// renderSvg compiles a D2 diagram source string into a SVG representation.
 // It uses D2 graph layout and rendering options to generate the visual output.
 // The function returns the generated SVG as a byte slice.
-func (c *Component) RenderSvg() []byte {
-	contents := c.FullDiagram()
-
+func renderSvg(contents string) []byte {
 	ruler, _ := textmeasure.NewRuler()
 	layoutResolver := func(engine string) (d2graph.LayoutGraph, error) {
 		return d2elklayout.DefaultLayout, nil
@@ -148,60 +371,366 @@ func (c *Component) RenderSvg() []byte {
 	return out
 }
 
-// FullDiagram generates a D2 diagram string that represents all incoming and outgoing
-// data flows of the component. The diagram is in the D2 graph format.
-func (c *Component) FullDiagram() string {
-	return fmt.Sprintf("%s\n%s", c.UpstreamDiagram(), c.DownstreamDiagram())
+// renderPng rasterizes a D2 diagram source string into a PNG file at outPath
+// by shelling out to cfg.D2BinaryPath, since the d2 Go library does not ship
+// a built-in rasterizer.
+func renderPng(cfg *Config, contents, outPath string) error {
+	tmp, err := os.CreateTemp("", "tiramisu-*.d2")
+	if err != nil {
+		return fmt.Errorf("creating temporary d2 source: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(contents); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing temporary d2 source: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(cfg.D2BinaryPath, tmp.Name(), outPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %s: %w (%s)", cfg.D2BinaryPath, err, out)
+	}
+
+	return nil
 }
 
-// UpstreamDiagram generates a D2 diagram string that represents all incoming data
-// flows to the component. Each incoming data flow is represented as an arrow
-// from another component to the current component. The arrow is labeled with
-// the short name of the other component. The diagram is in the D2 graph
-// format.
-func (c *Component) UpstreamDiagram() string {
-	relations := []string{}
-	for _, dep := range c.Dependencies {
-		if dep.TypeName != "MeasureSheet" {
-			continue
+// RenderSystemDiagram produces a single SVG showing every retained component
+// and edge in store at once, with components grouped into D2 containers by
+// their parent folder. It lets a maintainer see the full data-flow landscape
+// without opening hundreds of per-component diagrams.
+func RenderSystemDiagram(store map[int]*Component, folders map[int]string, cfg *Config) []byte {
+	byParent := map[int][]*Component{}
+	for _, comp := range store {
+		byParent[comp.ParentId] = append(byParent[comp.ParentId], comp)
+	}
+
+	parentIds := make([]int, 0, len(byParent))
+	for parentId := range byParent {
+		parentIds = append(parentIds, parentId)
+	}
+	sort.Ints(parentIds)
+
+	var lines []string
+	qualified := map[int]string{}
+	for _, parentId := range parentIds {
+		label := d2Escape(folderLabel(parentId, folders))
+		lines = append(lines, fmt.Sprintf("'%s': {", label))
+		for _, comp := range byParent[parentId] {
+			name := d2Escape(comp.ShortName(cfg))
+			qualified[comp.Id] = fmt.Sprintf("'%s'.'%s'", label, name)
+			lines = append(lines, fmt.Sprintf("  '%s'", name))
 		}
+		lines = append(lines, "}")
+	}
 
-		if dep.Id == c.Id {
-			continue
+	for _, e := range storeEdges(store, cfg) {
+		lines = append(lines, formatD2Edge(qualified[e.From.Id], qualified[e.To.Id], cfg, e.DependencyType))
+	}
+
+	return renderSvg(strings.Join(lines, "\n"))
+}
+
+// folderLabel returns the human-readable name of the folder identified by
+// parentId, falling back to the raw ID when folders has no non-empty name for
+// it (e.g. the parent is not a folder entry in the input).
+func folderLabel(parentId int, folders map[int]string) string {
+	if name, ok := folders[parentId]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("folder %d", parentId)
+}
+
+// d2Escape escapes a string for safe embedding inside a single-quoted D2
+// string (e.g. a container key). In D2, a literal single quote within a
+// single-quoted string is escaped by doubling it, and a raw newline isn't
+// allowed at all, so folder names like "Tom's Archive" don't break the
+// generated D2 source.
+func d2Escape(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// storeEdges returns every distinct edge between two retained components in
+// store, honoring cfg.IncludedTypeNames the same way the per-component
+// diagrams do.
+func storeEdges(store map[int]*Component, cfg *Config) []Edge {
+	seen := map[edgeKey]bool{}
+	var edges []Edge
+	for _, comp := range store {
+		for _, dep := range comp.Dependents {
+			if !slices.Contains(cfg.IncludedTypeNames, dep.To.TypeName) || dep.To.Id == comp.Id {
+				continue
+			}
+
+			key := edgeKey{From: comp.Id, To: dep.To.Id}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			edges = append(edges, Edge{From: comp, To: dep.To, DependencyType: dep.DependencyType})
 		}
+	}
+	return edges
+}
 
-		relations = append(relations, fmt.Sprintf("'%s' -> '%s'", dep.ShortName(), c.ShortName()))
+// formatD2Edge renders a D2 arrow between from and to, labeled with the
+// human-readable names and styled with the stroke color configured in
+// cfg.DependencyTypeStyles for depType. from and to must already be quoted
+// D2 key expressions (e.g. "'name'" or "'folder'.'name'" for a nested
+// container key). Dependency type codes without a configured style
+// contribute neither a label nor a color.
+func formatD2Edge(from, to string, cfg *Config, depType []int) string {
+	label, color := dependencyStyle(cfg, depType)
+
+	arrow := fmt.Sprintf("%s -> %s", from, to)
+	if label != "" {
+		arrow = fmt.Sprintf("%s: %s", arrow, label)
+	}
+	if color == "" {
+		return arrow
 	}
 
-	return strings.Join(relations, "\n")
+	return fmt.Sprintf("%s {\n  style.stroke: \"%s\"\n}", arrow, color)
 }
 
-// DownstreamDiagram generates a D2 diagram string that represents all outgoing data
-// flows from the component. Each outgoing data flow is represented as an arrow
-// from the current component to another component. The arrow is labeled with
-// the short name of the other component. The diagram is in the D2 graph format.
-func (c *Component) DownstreamDiagram() string {
-	relations := []string{}
-	for _, dep := range c.Dependents {
-		if dep.TypeName != "MeasureSheet" {
+// dependencyStyle resolves the D2 label and stroke color for an edge from its
+// DependencyType codes, using cfg.DependencyTypeStyles. Labels for every
+// recognized code are joined with ", "; the color of the first recognized
+// code is used. Codes without a configured style are ignored.
+func dependencyStyle(cfg *Config, depType []int) (label, color string) {
+	var labels []string
+	for _, code := range depType {
+		style, ok := cfg.DependencyTypeStyles[code]
+		if !ok {
 			continue
 		}
 
-		if dep.Id == c.Id {
-			continue
+		labels = append(labels, style.Label)
+		if color == "" {
+			color = style.Color
 		}
+	}
 
-		relations = append(relations, fmt.Sprintf("'%s' -> '%s'", c.ShortName(), dep.ShortName()))
+	return strings.Join(labels, ", "), color
+}
+
+// exportEdges collects the edges to export for c: its upstream and
+// downstream data flows, each traversed up to the given depth.
+func (c *Component) exportEdges(cfg *Config, upstreamDepth, downstreamDepth int) []Edge {
+	return append(c.TraverseUpstream(upstreamDepth, cfg), c.TraverseDownstream(downstreamDepth, cfg)...)
+}
+
+// ToDOT renders the component's upstream/downstream data flow, up to
+// upstreamDepth/downstreamDepth hops, as Graphviz DOT source.
+func (c *Component) ToDOT(cfg *Config, upstreamDepth, downstreamDepth int) string {
+	return dotFromEdges(c.exportEdges(cfg, upstreamDepth, downstreamDepth), cfg)
+}
+
+// ToGraphML renders the component's upstream/downstream data flow, up to
+// upstreamDepth/downstreamDepth hops, as GraphML, for loading into
+// interactive graph explorers such as yEd or Gephi.
+func (c *Component) ToGraphML(cfg *Config, upstreamDepth, downstreamDepth int) string {
+	return graphmlFromEdges(c.exportEdges(cfg, upstreamDepth, downstreamDepth), cfg)
+}
+
+// ToMermaid renders the component's upstream/downstream data flow, up to
+// upstreamDepth/downstreamDepth hops, as a Mermaid flowchart, suitable for
+// embedding directly in Git-hosted Markdown docs.
+func (c *Component) ToMermaid(cfg *Config, upstreamDepth, downstreamDepth int) string {
+	return mermaidFromEdges(c.exportEdges(cfg, upstreamDepth, downstreamDepth), cfg)
+}
+
+// StoreToDOT renders every retained component and edge in store as Graphviz
+// DOT source.
+func StoreToDOT(store map[int]*Component, cfg *Config) string {
+	return dotFromEdges(storeEdges(store, cfg), cfg)
+}
+
+// StoreToGraphML renders every retained component and edge in store as
+// GraphML, for loading into interactive graph explorers such as yEd or Gephi.
+func StoreToGraphML(store map[int]*Component, cfg *Config) string {
+	return graphmlFromEdges(storeEdges(store, cfg), cfg)
+}
+
+// StoreToMermaid renders every retained component and edge in store as a
+// Mermaid flowchart, suitable for embedding directly in Git-hosted Markdown
+// docs.
+func StoreToMermaid(store map[int]*Component, cfg *Config) string {
+	return mermaidFromEdges(storeEdges(store, cfg), cfg)
+}
+
+// edgeNodes returns the distinct components referenced by edges, sorted by
+// ID for deterministic output.
+func edgeNodes(edges []Edge) []*Component {
+	byId := map[int]*Component{}
+	for _, e := range edges {
+		byId[e.From.Id] = e.From
+		byId[e.To.Id] = e.To
+	}
+
+	ids := make([]int, 0, len(byId))
+	for id := range byId {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	nodes := make([]*Component, len(ids))
+	for i, id := range ids {
+		nodes[i] = byId[id]
+	}
+	return nodes
+}
+
+// dotFromEdges renders edges as Graphviz DOT source, with each node labeled
+// by its short name and each edge labeled/colored by its DependencyType.
+func dotFromEdges(edges []Edge, cfg *Config) string {
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+
+	for _, n := range edgeNodes(edges) {
+		fmt.Fprintf(&b, "  %q [label=%q];\n", strconv.Itoa(n.Id), n.ShortName(cfg))
+	}
+
+	for _, e := range edges {
+		label, color := dependencyStyle(cfg, e.DependencyType)
+		var attrs []string
+		if label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", label))
+		}
+		if color != "" {
+			attrs = append(attrs, fmt.Sprintf("color=%q", color))
+		}
+
+		attrSuffix := ""
+		if len(attrs) > 0 {
+			attrSuffix = fmt.Sprintf(" [%s]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintf(&b, "  %q -> %q%s;\n", strconv.Itoa(e.From.Id), strconv.Itoa(e.To.Id), attrSuffix)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphmlFromEdges renders edges as GraphML, with each node and edge carrying
+// a "label" data attribute holding the short name / dependency type label.
+func graphmlFromEdges(edges []Edge, cfg *Config) string {
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	b.WriteString("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	b.WriteString("  <key id=\"nlabel\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	b.WriteString("  <key id=\"elabel\" for=\"edge\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	b.WriteString("  <graph id=\"G\" edgedefault=\"directed\">\n")
+
+	for _, n := range edgeNodes(edges) {
+		fmt.Fprintf(&b, "    <node id=%q><data key=\"nlabel\">%s</data></node>\n", strconv.Itoa(n.Id), xmlEscape(n.ShortName(cfg)))
+	}
+
+	for _, e := range edges {
+		label, _ := dependencyStyle(cfg, e.DependencyType)
+		fmt.Fprintf(&b, "    <edge source=%q target=%q><data key=\"elabel\">%s</data></edge>\n",
+			strconv.Itoa(e.From.Id), strconv.Itoa(e.To.Id), xmlEscape(label))
+	}
+
+	b.WriteString("  </graph>\n")
+	b.WriteString("</graphml>\n")
+	return b.String()
+}
+
+// xmlEscape escapes the characters that are significant in XML text content
+// and attribute values.
+func xmlEscape(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		`"`, "&quot;",
+		"'", "&apos;",
+	).Replace(s)
+}
+
+// mermaidEscape escapes a string for safe embedding inside a quoted Mermaid
+// node label or a `-->|label|` edge label. Mermaid doesn't understand Go's
+// backslash escaping (e.g. from "%q"); it uses "#code;" character references
+// instead, so a literal "#" must be escaped first to avoid colliding with
+// references produced by the other replacements.
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, "#", "#35;")
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "|", "#124;")
+	s = strings.ReplaceAll(s, "[", "#91;")
+	s = strings.ReplaceAll(s, "]", "#93;")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// mermaidFromEdges renders edges as a Mermaid flowchart, with each node
+// labeled by its short name and each edge labeled by its DependencyType.
+func mermaidFromEdges(edges []Edge, cfg *Config) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for _, n := range edgeNodes(edges) {
+		fmt.Fprintf(&b, "  %d[\"%s\"]\n", n.Id, mermaidEscape(n.ShortName(cfg)))
+	}
+
+	for _, e := range edges {
+		label, _ := dependencyStyle(cfg, e.DependencyType)
+		if label != "" {
+			fmt.Fprintf(&b, "  %d -->|%s| %d\n", e.From.Id, mermaidEscape(label), e.To.Id)
+		} else {
+			fmt.Fprintf(&b, "  %d --> %d\n", e.From.Id, e.To.Id)
+		}
+	}
+
+	return b.String()
+}
+
+// FullDiagram generates a D2 diagram string that represents all incoming and outgoing
+// data flows of the component, traversed up to upstreamDepth/downstreamDepth hops.
+// The diagram is in the D2 graph format.
+func (c *Component) FullDiagram(cfg *Config, upstreamDepth, downstreamDepth int) string {
+	return fmt.Sprintf("%s\n%s", c.UpstreamDiagram(cfg, upstreamDepth), c.DownstreamDiagram(cfg, downstreamDepth))
+}
+
+// UpstreamDiagram generates a D2 diagram string that represents all incoming data
+// flows to the component, up to depth hops away (see TraverseUpstream). Each
+// incoming data flow is represented as an arrow from another component to the
+// component it flows into, labeled and styled by its DependencyType (see
+// formatD2Edge). The diagram is in the D2 graph format.
+func (c *Component) UpstreamDiagram(cfg *Config, depth int) string {
+	relations := []string{}
+	for _, e := range c.TraverseUpstream(depth, cfg) {
+		relations = append(relations, formatD2Edge(fmt.Sprintf("'%s'", d2Escape(e.From.ShortName(cfg))), fmt.Sprintf("'%s'", d2Escape(e.To.ShortName(cfg))), cfg, e.DependencyType))
+	}
+
+	return strings.Join(relations, "\n")
+}
+
+// DownstreamDiagram generates a D2 diagram string that represents all outgoing data
+// flows from the component, up to depth hops away (see TraverseDownstream).
+// Each outgoing data flow is represented as an arrow from a component to
+// another component it flows into, labeled and styled by its DependencyType
+// (see formatD2Edge). The diagram is in the D2 graph format.
+func (c *Component) DownstreamDiagram(cfg *Config, depth int) string {
+	relations := []string{}
+	for _, e := range c.TraverseDownstream(depth, cfg) {
+		relations = append(relations, formatD2Edge(fmt.Sprintf("'%s'", d2Escape(e.From.ShortName(cfg))), fmt.Sprintf("'%s'", d2Escape(e.To.ShortName(cfg))), cfg, e.DependencyType))
 	}
 
 	return strings.Join(relations, "\n")
 }
 
-// Preprocess filters the given entries based on specific criteria and returns a
+// Preprocess filters the given entries based on the criteria in cfg and returns a
 // slice of entries that meet these criteria. The function ignores entries that
-// are folders, have zero direct references, belong to ignored parent IDs, or
-// are not of the "MeasureSheet" type.
-func Preprocess(entries []Entry) []Entry {
+// are folders, have zero direct references, belong to cfg.IgnoredParentIds, or
+// whose TypeName is not in cfg.IncludedTypeNames.
+func Preprocess(entries []Entry, cfg *Config) []Entry {
 	var preprocessed []Entry
 	for _, entry := range entries {
 		// ignore folders
@@ -215,12 +744,12 @@ func Preprocess(entries []Entry) []Entry {
 		}
 
 		// ignore entries part of ignored folders (archive)
-		if slices.Contains(IGNORE_WITH_PARENT_ID, entry.ParentId) {
+		if slices.Contains(cfg.IgnoredParentIds, entry.ParentId) {
 			continue
 		}
 
-		// only measure sheets
-		if entry.TypeName != "MeasureSheet" {
+		// only entries of the configured type(s)
+		if !slices.Contains(cfg.IncludedTypeNames, entry.TypeName) {
 			continue
 		}
 
@@ -245,8 +774,8 @@ func ResolveRelations(entries []Entry) map[int]*Component {
 	for _, entry := range entries {
 		for _, ref := range entry.DirectReferences {
 			if _, ok := store[ref.Id]; ok {
-				store[entry.Id].AddDependency(store[ref.Id])
-				store[ref.Id].AddDependent(store[entry.Id])
+				store[entry.Id].AddDependency(store[ref.Id], ref.DependencyType)
+				store[ref.Id].AddDependent(store[entry.Id], ref.DependencyType)
 			}
 		}
 	}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"oss.terrastruct.com/d2/d2parser"
+)
+
+// TestTraverseUpstreamDiamond exercises a diamond-shaped dependency graph
+// where the same upstream component (C) is reachable via two paths of
+// different lengths. A DFS that permanently caps a node's exploration at the
+// hop budget it was first reached with would miss edges beyond the node on
+// whichever path arrives last, even though they're within the requested
+// depth via the shorter path.
+func TestTraverseUpstreamDiamond(t *testing.T) {
+	cfg := &Config{IncludedTypeNames: []string{"T"}}
+
+	a := &Component{Id: 1, Name: "A", TypeName: "T"}
+	x := &Component{Id: 2, Name: "X", TypeName: "T"}
+	y := &Component{Id: 3, Name: "Y", TypeName: "T"}
+	b := &Component{Id: 4, Name: "B", TypeName: "T"}
+	c := &Component{Id: 5, Name: "C", TypeName: "T"}
+	d := &Component{Id: 6, Name: "D", TypeName: "T"}
+
+	// A <- Y <- B <- C (3 hops to C), added first so a DFS would reach C via
+	// this longer path before the shorter one below.
+	a.AddDependency(y, nil)
+	y.AddDependency(b, nil)
+	b.AddDependency(c, nil)
+
+	// A <- X <- C (2 hops to C), a shorter path to the same node.
+	a.AddDependency(x, nil)
+	x.AddDependency(c, nil)
+
+	// C's own upstream, only reachable by continuing past C.
+	c.AddDependency(d, nil)
+
+	edges := a.TraverseUpstream(3, cfg)
+
+	found := false
+	for _, e := range edges {
+		if e.From.Id == d.Id && e.To.Id == c.Id {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("TraverseUpstream(3) from A is missing the D->C edge, which is within depth via the 2-hop A<-X<-C path; got %d edges", len(edges))
+	}
+}
+
+// TestD2EscapeFolderLabel verifies that a folder name containing a single
+// quote (and a newline) produces a valid D2 container key once escaped,
+// matching how xmlEscape keeps names safe for the GraphML exporter.
+func TestD2EscapeFolderLabel(t *testing.T) {
+	label := d2Escape("Tom's Archive\nArchive")
+	src := "'" + label + "': {\n  '1'\n}\n"
+
+	if _, err := d2parser.Parse("", strings.NewReader(src), nil); err != nil {
+		t.Fatalf("escaped folder label produced invalid D2 source: %v\nsource:\n%s", err, src)
+	}
+}
+
+// mermaidNodeRe matches a well-formed Mermaid flowchart node declaration: the
+// quoted label must not contain an unescaped double quote, since that would
+// terminate the string early and leave trailing text the parser can't place.
+var mermaidNodeRe = regexp.MustCompile(`^  \d+\["[^"]*"\]$`)
+
+// mermaidEdgeRe matches a well-formed Mermaid flowchart edge with a label:
+// the label between the pipes must not itself contain an unescaped pipe,
+// since that would terminate the label early.
+var mermaidEdgeRe = regexp.MustCompile(`^  \d+ -->\|[^|]*\| \d+$`)
+
+// TestMermaidEscapeNodeAndEdgeLabels verifies that a component whose
+// ShortName falls back to a raw name containing quote/bracket characters
+// (e.g. a ShortNameRegex miss), and a dependency style label containing a
+// pipe, still produce well-formed Mermaid flowchart syntax once escaped,
+// matching how xmlEscape keeps names safe for the GraphML exporter.
+func TestMermaidEscapeNodeAndEdgeLabels(t *testing.T) {
+	cfg := &Config{
+		ShortNameRegex: "^nomatch$",
+		DependencyTypeStyles: map[int]DependencyStyle{
+			1: {Label: "reads | writes", Color: "#2563eb"},
+		},
+	}
+
+	from := &Component{Id: 1, Name: `1.2["evil`}
+	to := &Component{Id: 2, Name: "2.0 Target"}
+	edges := []Edge{{From: from, To: to, DependencyType: []int{1}}}
+
+	out := mermaidFromEdges(edges, cfg)
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "flowchart LR" {
+			continue
+		}
+		if strings.Contains(line, "-->") {
+			if !mermaidEdgeRe.MatchString(line) {
+				t.Errorf("malformed mermaid edge line: %q", line)
+			}
+			continue
+		}
+		if !mermaidNodeRe.MatchString(line) {
+			t.Errorf("malformed mermaid node line: %q", line)
+		}
+	}
+}